@@ -0,0 +1,111 @@
+// Copyright 2016 The Gem Authors. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/url"
+
+	"github.com/valyala/fasthttp"
+)
+
+// WithJSON marshals v as JSON, sets it as the request payload and
+// sets the Content-Type header to "application/json".
+func (t *Test) WithJSON(v interface{}) *Test {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("tests: failed to marshal JSON: %s", err))
+	}
+
+	t.Headers["Content-Type"] = "application/json"
+	t.Payload = string(body)
+
+	return t
+}
+
+// WithForm encodes values as a "application/x-www-form-urlencoded"
+// body and sets it as the request payload.
+func (t *Test) WithForm(values url.Values) *Test {
+	t.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+	t.Payload = values.Encode()
+
+	return t
+}
+
+// WithMultipart builds a "multipart/form-data" body from fields and
+// files, where files maps a form field name to a pair of file name
+// and file content, and sets it as the request payload.
+func (t *Test) WithMultipart(fields map[string]string, files map[string][2]string) *Test {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	for k, v := range fields {
+		if err := w.WriteField(k, v); err != nil {
+			panic(fmt.Sprintf("tests: failed to write multipart field: %s", err))
+		}
+	}
+
+	for field, file := range files {
+		fw, err := w.CreateFormFile(field, file[0])
+		if err != nil {
+			panic(fmt.Sprintf("tests: failed to create multipart file: %s", err))
+		}
+		if _, err := fw.Write([]byte(file[1])); err != nil {
+			panic(fmt.Sprintf("tests: failed to write multipart file: %s", err))
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		panic(fmt.Sprintf("tests: failed to close multipart writer: %s", err))
+	}
+
+	t.Headers["Content-Type"] = w.FormDataContentType()
+	t.Payload = buf.String()
+
+	return t
+}
+
+// WithQuery sets key=value in the request URL's query string,
+// replacing any value key already has there.
+func (t *Test) WithQuery(key, value string) *Test {
+	u, err := url.Parse(t.Url)
+	if err != nil {
+		panic(fmt.Sprintf("tests: failed to parse url %q: %s", t.Url, err))
+	}
+
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+
+	t.Url = u.String()
+
+	return t
+}
+
+// WithBasicAuth sets the Authorization header using HTTP Basic auth.
+func (t *Test) WithBasicAuth(user, pass string) *Test {
+	token := base64.StdEncoding.EncodeToString([]byte(user + ":" + pass))
+	t.Headers["Authorization"] = "Basic " + token
+
+	return t
+}
+
+// WithBearerToken sets the Authorization header using a Bearer token.
+func (t *Test) WithBearerToken(token string) *Test {
+	t.Headers["Authorization"] = "Bearer " + token
+
+	return t
+}
+
+// WithCookie adds a Cookie header built from c.
+func (t *Test) WithCookie(c *fasthttp.Cookie) *Test {
+	t.Headers["Cookie"] = fmt.Sprintf("%s=%s", c.Key(), c.Value())
+
+	return t
+}
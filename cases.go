@@ -0,0 +1,68 @@
+// Copyright 2016 The Gem Authors. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package tests
+
+import "testing"
+
+// Request describes the request side of a Case: any field left at
+// its zero value falls back to the same default Test uses.
+type Request struct {
+	Url      string
+	Method   string
+	Protocol string
+	Headers  map[string]string
+	Payload  string
+}
+
+// Case is a single named table-driven test case, combining a Request
+// with its Expect.
+type Case struct {
+	Name    string
+	Request Request
+	Expect  Expect
+}
+
+// RunCases runs each case as an isolated subtest via t.Run, in
+// parallel, each against its own fresh Test built from t's server.
+// A failing case is reported against its own name, instead of the
+// single shared error a plain loop without subtests would produce.
+func (t *Test) RunCases(tt *testing.T, cases []Case) {
+	for _, c := range cases {
+		c := c
+		tt.Run(c.Name, func(st *testing.T) {
+			st.Parallel()
+
+			ct := New(t.server)
+			ct.Timeout = t.Timeout
+			ct.Transport = t.Transport
+			applyRequest(ct, c.Request)
+			ct.expect = &c.Expect
+
+			if err := ct.Run(); err != nil {
+				st.Error(err)
+			}
+		})
+	}
+}
+
+// applyRequest copies the non-zero fields of r onto ct, leaving ct's
+// defaults in place for anything r doesn't set.
+func applyRequest(ct *Test, r Request) {
+	if r.Url != "" {
+		ct.Url = r.Url
+	}
+	if r.Method != "" {
+		ct.Method = r.Method
+	}
+	if r.Protocol != "" {
+		ct.Protocol = r.Protocol
+	}
+	if len(r.Headers) > 0 {
+		ct.Headers = r.Headers
+	}
+	if r.Payload != "" {
+		ct.Payload = r.Payload
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2016 The Gem Authors. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// JSON add an expected JSON response body. The actual body and v are
+// both decoded into interface{} and re-marshaled to a canonical form
+// before being compared, so field order and whitespace don't matter.
+func (e *Expect) JSON(v interface{}) *Expect {
+	return e.Custom(func(resp fasthttp.Response) error {
+		var actual interface{}
+		if err := json.Unmarshal(resp.Body(), &actual); err != nil {
+			return fmt.Errorf("failed to decode response body as JSON: %s", err)
+		}
+
+		expected, err := canonicalize(v)
+		if err != nil {
+			return fmt.Errorf("failed to decode expected value as JSON: %s", err)
+		}
+
+		if !reflect.DeepEqual(actual, expected) {
+			return fmt.Errorf("JSON mismatch:\n%s", diffJSON(expected, actual))
+		}
+
+		return nil
+	})
+}
+
+// JSONPath add an expected value located at expr within the response
+// body. expr supports a small subset of JSONPath: dotted field access
+// and integer array indices, e.g. "$.a.b[0].c".
+func (e *Expect) JSONPath(expr string, expected interface{}) *Expect {
+	return e.Custom(func(resp fasthttp.Response) error {
+		var tree interface{}
+		if err := json.Unmarshal(resp.Body(), &tree); err != nil {
+			return fmt.Errorf("failed to decode response body as JSON: %s", err)
+		}
+
+		actual, err := resolveJSONPath(tree, expr)
+		if err != nil {
+			return fmt.Errorf("JSONPath %q: %s", expr, err)
+		}
+
+		want, err := canonicalize(expected)
+		if err != nil {
+			return fmt.Errorf("failed to decode expected value as JSON: %s", err)
+		}
+
+		if !reflect.DeepEqual(actual, want) {
+			return fmt.Errorf("JSONPath %q mismatch:\n%s", expr, diffJSON(want, actual))
+		}
+
+		return nil
+	})
+}
+
+// JSONContains add an expected fragment that must be present in the
+// response body, matched from its top level down: every key/value in
+// fragment must appear at the same place in the actual tree, and
+// arrays in fragment must match as a multiset subset of the actual
+// array.
+func (e *Expect) JSONContains(fragment interface{}) *Expect {
+	return e.Custom(func(resp fasthttp.Response) error {
+		var actual interface{}
+		if err := json.Unmarshal(resp.Body(), &actual); err != nil {
+			return fmt.Errorf("failed to decode response body as JSON: %s", err)
+		}
+
+		want, err := canonicalize(fragment)
+		if err != nil {
+			return fmt.Errorf("failed to decode expected fragment as JSON: %s", err)
+		}
+
+		if !containsJSON(want, actual) {
+			return fmt.Errorf("JSON does not contain expected fragment:\n%s", diffJSON(want, actual))
+		}
+
+		return nil
+	})
+}
+
+// canonicalize re-marshals v and decodes it back into interface{}, so
+// it can be compared against a response body decoded the same way.
+func canonicalize(v interface{}) (interface{}, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var out interface{}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// resolveJSONPath walks tree following expr, a dotted path optionally
+// prefixed with "$" and using "[n]" for array indices.
+func resolveJSONPath(tree interface{}, expr string) (interface{}, error) {
+	expr = strings.TrimPrefix(expr, "$")
+	expr = strings.TrimPrefix(expr, ".")
+
+	cur := tree
+	for _, token := range splitJSONPath(expr) {
+		if token == "" {
+			continue
+		}
+
+		if idx, err := strconv.Atoi(token); err == nil {
+			arr, ok := cur.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("expected array, got %T", cur)
+			}
+			if idx < 0 || idx >= len(arr) {
+				return nil, fmt.Errorf("index %d out of range", idx)
+			}
+			cur = arr[idx]
+			continue
+		}
+
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected object, got %T", cur)
+		}
+		v, ok := m[token]
+		if !ok {
+			return nil, fmt.Errorf("key %q not found", token)
+		}
+		cur = v
+	}
+
+	return cur, nil
+}
+
+// splitJSONPath turns "a.b[0].c" into ["a", "b", "0", "c"].
+func splitJSONPath(expr string) []string {
+	var tokens []string
+	for _, part := range strings.Split(expr, ".") {
+		part = strings.Replace(part, "]", "", -1)
+		tokens = append(tokens, strings.Split(part, "[")...)
+	}
+
+	return tokens
+}
+
+// containsJSON reports whether actual contains fragment: objects
+// require every key in fragment to be present and equal in actual,
+// and arrays in fragment must be a multiset subset of actual.
+func containsJSON(fragment, actual interface{}) bool {
+	switch f := fragment.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, v := range f {
+			av, ok := a[k]
+			if !ok || !containsJSON(v, av) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok {
+			return false
+		}
+		return matchJSONArray(f, a)
+	default:
+		return reflect.DeepEqual(fragment, actual)
+	}
+}
+
+// matchJSONArray reports whether every element of f can be matched to
+// a distinct element of a, using Kuhn's augmenting-path algorithm for
+// bipartite matching. A greedy first-fit assignment isn't enough: an
+// earlier fragment element can be satisfiable by more than one actual
+// element, and picking the wrong one can starve a later, pickier
+// fragment element of the only actual element it matches.
+func matchJSONArray(f, a []interface{}) bool {
+	matchedBy := make([]int, len(a))
+	for i := range matchedBy {
+		matchedBy[i] = -1
+	}
+
+	for fi := range f {
+		visited := make([]bool, len(a))
+		if !augmentJSONMatch(f, a, fi, visited, matchedBy) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// augmentJSONMatch tries to match fragment element fi to some actual
+// element, freeing up an already-matched actual element (and
+// recursively re-matching whichever fragment element it belonged to)
+// if that unlocks an assignment.
+func augmentJSONMatch(f, a []interface{}, fi int, visited []bool, matchedBy []int) bool {
+	for ai := range a {
+		if visited[ai] || !containsJSON(f[fi], a[ai]) {
+			continue
+		}
+		visited[ai] = true
+
+		if matchedBy[ai] == -1 || augmentJSONMatch(f, a, matchedBy[ai], visited, matchedBy) {
+			matchedBy[ai] = fi
+			return true
+		}
+	}
+
+	return false
+}
+
+// diffJSON renders expected and actual as indented JSON for error
+// messages.
+func diffJSON(expected, actual interface{}) string {
+	expectedJSON, _ := json.MarshalIndent(expected, "", "  ")
+	actualJSON, _ := json.MarshalIndent(actual, "", "  ")
+
+	return fmt.Sprintf("--- expected\n%s\n--- actual\n%s", expectedJSON, actualJSON)
+}
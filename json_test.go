@@ -0,0 +1,89 @@
+// Copyright 2016 The Gem Authors. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestExpect_JSON(t *testing.T) {
+	var resp fasthttp.Response
+	resp.SetBodyString(`{"a":1,"b":"x"}`)
+
+	e := new(Expect)
+	e.JSON(map[string]interface{}{"a": float64(1), "b": "x"})
+	if err := e.check(resp); err != nil {
+		t.Error(err)
+	}
+
+	e2 := new(Expect)
+	e2.JSON(map[string]interface{}{"a": float64(2), "b": "x"})
+	if err := e2.check(resp); err == nil {
+		t.Error("expected mismatch error, got nil")
+	}
+}
+
+func TestExpect_JSONPath(t *testing.T) {
+	var resp fasthttp.Response
+	resp.SetBodyString(`{"a":{"b":[{"c":42}]}}`)
+
+	e := new(Expect)
+	e.JSONPath("$.a.b[0].c", float64(42))
+	if err := e.check(resp); err != nil {
+		t.Error(err)
+	}
+
+	e2 := new(Expect)
+	e2.JSONPath("$.a.b[0].c", float64(7))
+	if err := e2.check(resp); err == nil {
+		t.Error("expected mismatch error, got nil")
+	}
+
+	e3 := new(Expect)
+	e3.JSONPath("$.a.missing", "x")
+	if err := e3.check(resp); err == nil {
+		t.Error("expected path-not-found error, got nil")
+	}
+}
+
+func TestExpect_JSONContains(t *testing.T) {
+	var resp fasthttp.Response
+	resp.SetBodyString(`{"a":1,"b":2,"list":[1,2,3]}`)
+
+	e := new(Expect)
+	e.JSONContains(map[string]interface{}{
+		"a":    float64(1),
+		"list": []interface{}{float64(3), float64(2)},
+	})
+	if err := e.check(resp); err != nil {
+		t.Error(err)
+	}
+
+	e2 := new(Expect)
+	e2.JSONContains(map[string]interface{}{"a": float64(99)})
+	if err := e2.check(resp); err == nil {
+		t.Error("expected mismatch error, got nil")
+	}
+}
+
+// TestExpect_JSONContains_ArrayAssignment guards against a greedy
+// first-fit array matcher: {} can match either actual element, but
+// {"k":1} only matches actual[0], so a correct matcher must assign
+// {}->actual[1] to leave actual[0] free.
+func TestExpect_JSONContains_ArrayAssignment(t *testing.T) {
+	var resp fasthttp.Response
+	resp.SetBodyString(`[{"k":1},{"k":2}]`)
+
+	e := new(Expect)
+	e.JSONContains([]interface{}{
+		map[string]interface{}{},
+		map[string]interface{}{"k": float64(1)},
+	})
+	if err := e.check(resp); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2016 The Gem Authors. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Session wraps a server and persists cookies and custom headers
+// across a sequence of Test calls, so a caller can script multi-step
+// flows such as "login, then use the session, then logout" against
+// an otherwise one-shot Test/Run API.
+type Session struct {
+	server server
+
+	// Headers are sent on every request run through the session, in
+	// addition to whatever Test.Headers a given Test sets.
+	Headers map[string]string
+
+	jar      map[string]map[string]string // path -> cookie name -> value
+	lastResp fasthttp.Response
+}
+
+// NewSession returns a Session bound to server, with an empty cookie
+// jar.
+func NewSession(server server) *Session {
+	return &Session{
+		server:  server,
+		Headers: make(map[string]string),
+		jar:     make(map[string]map[string]string),
+	}
+}
+
+// Run runs test against the session's server: it injects the
+// session's headers and any cookies matching test.Url, runs the
+// test, then stores cookies from the response for subsequent calls.
+func (s *Session) Run(test *Test) error {
+	test.server = s.server
+	if test.Headers == nil {
+		test.Headers = make(map[string]string)
+	}
+	for k, v := range s.Headers {
+		test.Headers[k] = v
+	}
+	if cookieHeader := s.cookieHeader(test.Url); cookieHeader != "" {
+		test.Headers["Cookie"] = cookieHeader
+	}
+	if test.Transport == nil {
+		test.Transport = ServeConnTransport{}
+	}
+
+	ctx := test.ctx
+	if ctx == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), test.Timeout)
+		defer cancel()
+	} else if test.cancel != nil {
+		cancel := test.cancel
+		test.cancel = nil
+		defer cancel()
+	}
+
+	resp, err := test.Transport.RoundTrip(ctx, test)
+	s.lastResp = resp
+	if err != nil {
+		return err
+	}
+
+	s.storeCookies(test.Url, resp)
+
+	return test.Expect().check(resp)
+}
+
+// LastResponse returns the response of the most recent Run call, so
+// a Func in one step can inspect it while building the next step.
+func (s *Session) LastResponse() fasthttp.Response {
+	return s.lastResp
+}
+
+// storeCookies records every Set-Cookie header in resp, keyed by the
+// cookie's own Path (falling back to "/" when unset).
+func (s *Session) storeCookies(requestURL string, resp fasthttp.Response) {
+	resp.Header.VisitAllCookie(func(key, value []byte) {
+		c := fasthttp.AcquireCookie()
+		defer fasthttp.ReleaseCookie(c)
+
+		if err := c.ParseBytes(value); err != nil {
+			return
+		}
+
+		path := string(c.Path())
+		if path == "" {
+			path = "/"
+		}
+
+		if s.jar[path] == nil {
+			s.jar[path] = make(map[string]string)
+		}
+		s.jar[path][string(c.Key())] = string(c.Value())
+	})
+}
+
+// cookieHeader builds a "Cookie: " header value from every jar entry
+// whose path, per RFC 6265 path matching, is a match for requestURL's
+// path: jarPath == path, jarPath == "/", or path extends jarPath at a
+// "/" boundary. A plain string prefix would let a cookie stored for
+// "/admin" leak onto "/administrator".
+func (s *Session) cookieHeader(requestURL string) string {
+	path := requestURL
+	if u, err := url.Parse(requestURL); err == nil {
+		path = u.Path
+	}
+
+	var pairs []string
+	for jarPath, cookies := range s.jar {
+		if jarPath != "/" && path != jarPath && !strings.HasPrefix(path, jarPath+"/") {
+			continue
+		}
+		for k, v := range cookies {
+			pairs = append(pairs, k+"="+v)
+		}
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, "; ")
+}
@@ -0,0 +1,90 @@
+// Copyright 2016 The Gem Authors. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestSession_CookieJar(t *testing.T) {
+	var gotCookie string
+
+	srv := &fasthttp.Server{
+		Handler: func(ctx *fasthttp.RequestCtx) {
+			if v := ctx.Request.Header.Cookie(cookieKey); len(v) > 0 {
+				gotCookie = string(v)
+			}
+
+			setCookie := &fasthttp.Cookie{}
+			setCookie.SetKey(cookieKey)
+			setCookie.SetValue(cookieValue)
+			setCookie.SetPath("/")
+			ctx.Response.Header.SetCookie(setCookie)
+
+			ctx.SetStatusCode(fasthttp.StatusOK)
+		},
+	}
+
+	sess := NewSession(srv)
+
+	if err := sess.Run(New(srv)); err != nil {
+		t.Error(err)
+	}
+
+	if err := sess.Run(New(srv, "/next")); err != nil {
+		t.Error(err)
+	}
+
+	if gotCookie != cookieValue {
+		t.Errorf("expected session to send cookie %q on the second request, got %q", cookieValue, gotCookie)
+	}
+}
+
+func TestSession_CookieJarPathBoundary(t *testing.T) {
+	var gotCookie string
+
+	srv := &fasthttp.Server{
+		Handler: func(ctx *fasthttp.RequestCtx) {
+			gotCookie = string(ctx.Request.Header.Cookie(cookieKey))
+			ctx.SetStatusCode(fasthttp.StatusOK)
+		},
+	}
+
+	sess := NewSession(srv)
+	sess.jar["/admin"] = map[string]string{cookieKey: cookieValue}
+
+	if err := sess.Run(New(srv, "/administrator/panel")); err != nil {
+		t.Error(err)
+	}
+	if gotCookie != "" {
+		t.Errorf("expected cookie scoped to /admin not to leak onto /administrator/panel, got %q", gotCookie)
+	}
+
+	if err := sess.Run(New(srv, "/admin/users")); err != nil {
+		t.Error(err)
+	}
+	if gotCookie != cookieValue {
+		t.Errorf("expected cookie scoped to /admin to apply to /admin/users, got %q", gotCookie)
+	}
+}
+
+func TestSession_RunError(t *testing.T) {
+	srv, _ := fixture()
+	sess := NewSession(srv)
+
+	test := New(srv)
+	test.Expect().Status(fasthttp.StatusOK)
+
+	if err := sess.Run(test); err == nil {
+		t.Error("expected status mismatch error, got nil")
+	}
+
+	resp := sess.LastResponse()
+	if got := resp.StatusCode(); got != statusCode {
+		t.Errorf("expected LastResponse status %d, got %d", statusCode, got)
+	}
+}
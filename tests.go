@@ -58,11 +58,12 @@ Example
 package tests
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/valyala/fasthttp"
@@ -144,6 +145,11 @@ type Test struct {
 	Timeout time.Duration
 	rw      *readWriter
 
+	// Transport decides how the request reaches server. It defaults
+	// to ServeConnTransport{}; set it to NetTransport{} to exercise
+	// real connection-level behavior instead.
+	Transport Transport
+
 	// Request configuration
 	Url      string
 	Method   string
@@ -152,6 +158,12 @@ type Test struct {
 	Payload  string
 
 	expect *Expect
+
+	// ctx, when set via Deadline, overrides Timeout as the context
+	// passed to the Transport. cancel releases it once Run/RunCtx is
+	// done with it.
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 var (
@@ -159,16 +171,18 @@ var (
 	defaultUrl      = "/"
 	defaultProtocol = "HTTP/1.1"
 
-	// DefaultTimeout
-	DefaultTimeout = 200 * time.Microsecond
+	// DefaultTimeout is how long Run waits for the server to respond
+	// before giving up.
+	DefaultTimeout = 5 * time.Second
 )
 
 // New returns a Test instance with default configuration.
 func New(server server, args ...string) *Test {
 	t := &Test{
-		server:  server,
-		rw:      &readWriter{},
-		Timeout: DefaultTimeout,
+		server:    server,
+		rw:        &readWriter{},
+		Timeout:   DefaultTimeout,
+		Transport: ServeConnTransport{},
 
 		Url:      defaultUrl,
 		Method:   defaultMethod,
@@ -193,44 +207,62 @@ func New(server server, args ...string) *Test {
 	return t
 }
 
-var (
-	errTimeout = errors.New("timeout")
-)
-
 // Run run test and return an error,
 // return nil if everything is ok.
 func (t *Test) Run() (err error) {
-	t.initRW()
-
-	br := bufio.NewReader(&t.rw.w)
-	var resp fasthttp.Response
-	ch := make(chan error)
-	go func() {
-		ch <- t.server.ServeConn(t.rw)
-	}()
-
-	select {
-	case err = <-ch:
-		if err != nil {
-			return
-		}
-	case <-time.After(t.Timeout):
-		return errTimeout
+	ctx := t.ctx
+	if ctx == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), t.Timeout)
+		defer cancel()
 	}
 
-	if err = resp.Read(br); err != nil {
-		return fmt.Errorf("unexpected error when reading response: %s", err)
+	return t.RunCtx(ctx)
+}
+
+// RunCtx runs the test like Run, except ctx governs cancellation
+// instead of Timeout: when ctx is done, the in-flight request is
+// aborted and RunCtx returns immediately.
+func (t *Test) RunCtx(ctx context.Context) (err error) {
+	if t.cancel != nil {
+		cancel := t.cancel
+		t.cancel = nil
+		defer cancel()
 	}
-	if err = t.expect.check(resp); err != nil {
-		return
+
+	resp, err := t.Transport.RoundTrip(ctx, t)
+	if err != nil {
+		return err
 	}
 
-	return
+	return t.expect.check(resp)
+}
+
+// Deadline sets the context deadline Run and RunCtx use from a
+// (time.Time, bool) pair shaped like testing.T.Deadline, so a test
+// can do test.Deadline(t.Deadline()) to respect `go test -timeout`.
+// It is a no-op when ok is false, matching Deadline's own behavior
+// when no test timeout was set.
+func (t *Test) Deadline(deadline time.Time, ok bool) *Test {
+	if ok {
+		if t.cancel != nil {
+			t.cancel()
+		}
+		t.ctx, t.cancel = context.WithDeadline(context.Background(), deadline)
+	}
+
+	return t
 }
 
 func (t *Test) initRW() {
 	firstPart := fmt.Sprintf("%s %s %s", t.Method, t.Url, t.Protocol)
 
+	if t.Payload != "" {
+		if _, ok := t.Headers["Content-Length"]; !ok {
+			t.Headers["Content-Length"] = fmt.Sprintf("%d", len(t.Payload))
+		}
+	}
+
 	secondPart := ""
 	for k, v := range t.Headers {
 		secondPart += fmt.Sprintf("%s: %s", k, v) + line
@@ -248,22 +280,61 @@ func (t *Test) Expect() *Expect {
 	return t.expect
 }
 
+var errClosed = errors.New("tests: connection closed")
+
+// readWriter fakes a net.Conn over in-memory buffers. Close makes
+// subsequent Read/Write calls fail immediately, so once a cancelled
+// context has closed it, ServeConn can no longer read further
+// requests from or write responses to the connection. A handler that
+// is already running is not interrupted.
 type readWriter struct {
 	net.Conn
 	r bytes.Buffer
 	w bytes.Buffer
+
+	mu     sync.Mutex
+	closed bool
 }
 
 func (rw *readWriter) Close() error {
+	rw.mu.Lock()
+	rw.closed = true
+	rw.mu.Unlock()
+
 	return nil
 }
 
 // Read
 func (rw *readWriter) Read(b []byte) (int, error) {
+	rw.mu.Lock()
+	closed := rw.closed
+	rw.mu.Unlock()
+	if closed {
+		return 0, errClosed
+	}
+
 	return rw.r.Read(b)
 }
 
 // Write
 func (rw *readWriter) Write(b []byte) (int, error) {
+	rw.mu.Lock()
+	closed := rw.closed
+	rw.mu.Unlock()
+	if closed {
+		return 0, errClosed
+	}
+
 	return rw.w.Write(b)
 }
+
+// SetReadDeadline is a no-op: deadlines are enforced by the Transport
+// cancelling the request's context and closing the connection,
+// rather than by this fake conn tracking wall-clock time itself.
+func (rw *readWriter) SetReadDeadline(time.Time) error { return nil }
+
+// SetWriteDeadline is a no-op for the same reason as SetReadDeadline.
+func (rw *readWriter) SetWriteDeadline(time.Time) error { return nil }
+
+// SetDeadline is a no-op for the same reason as SetReadDeadline.
+func (rw *readWriter) SetDeadline(time.Time) error { return nil }
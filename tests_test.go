@@ -12,25 +12,48 @@ import (
 	"github.com/valyala/fasthttp"
 )
 
-var (
+const (
 	contextType = "text/html; charset=utf-8"
-	statusCode  = fasthttp.StatusBadRequest
-	respBody    = fasthttp.StatusMessage(fasthttp.StatusBadRequest)
+	respBody    = "Bad Request"
 
 	// header
 	headerKey   = "Custom-Header"
 	headerValue = "tests"
 
 	// cookie
-	cookie      = &fasthttp.Cookie{}
 	cookieKey   = "GOSESSION"
 	cookieValue = "GOSESSION_VALUE"
+)
 
-	// Fake server.
-	srv = &fasthttp.Server{}
+var statusCode = fasthttp.StatusBadRequest
 
-	testParams = make([]param, 0)
-)
+// fixture builds an isolated server + cookie pair, so each caller
+// gets its own state instead of sharing package-level globals.
+func fixture() (*fasthttp.Server, *fasthttp.Cookie) {
+	cookie := &fasthttp.Cookie{}
+	cookie.SetKey(cookieKey)
+	cookie.SetValue(cookieValue)
+
+	srv := &fasthttp.Server{
+		Handler: func(ctx *fasthttp.RequestCtx) {
+			if string(ctx.Path()) == "/timeout" {
+				time.Sleep(time.Millisecond * 200)
+			}
+
+			if len(ctx.Request.Header.Peek(headerKey)) > 0 {
+				ctx.Response.Header.SetBytesV(headerKey, ctx.Request.Header.Peek(headerKey))
+			}
+
+			ctx.SetStatusCode(statusCode)
+			ctx.SetContentType(contextType)
+			ctx.SetBodyString(respBody)
+
+			ctx.Response.Header.SetCookie(cookie)
+		},
+	}
+
+	return srv, cookie
+}
 
 type param struct {
 	expectStatus  int
@@ -44,100 +67,67 @@ type param struct {
 	reqProtocol string
 	reqHeaders  map[string]string
 	reqPayload  string
+	reqTimeout  time.Duration
 }
 
-func init() {
-	cookie.SetKey(cookieKey)
-	cookie.SetValue(cookieValue)
-
-	srv.Handler = func(ctx *fasthttp.RequestCtx) {
-		if string(ctx.Path()) == "/timeout" {
-			time.Sleep(time.Millisecond * 200)
-		}
-
-		if len(ctx.Request.Header.Peek(headerKey)) > 0 {
-			ctx.Response.Header.SetBytesV(headerKey, ctx.Request.Header.Peek(headerKey))
-		}
-
-		ctx.SetStatusCode(statusCode)
-		ctx.SetContentType(contextType)
-		ctx.SetBodyString(respBody)
-
-		ctx.Response.Header.SetCookie(cookie)
-	}
-
-	// Correct status.
-	testParams = append(testParams, param{
-		expectStatus: statusCode,
-	})
-	// Incorrect status.
-	testParams = append(testParams, param{
-		expectErr:    true,
-		expectStatus: fasthttp.StatusGatewayTimeout,
-	})
-
-	// Correct Content-Type.
-	testParams = append(testParams, param{
-		expectHeaders: map[string]string{
-			"Content-Type": contextType,
-		},
-	})
-	// Incorrect Content-Type.
-	testParams = append(testParams, param{
-		expectErr: true,
-		expectHeaders: map[string]string{
-			"Content-Type": "application/json; charset=utf-8",
+// buildParams returns the param table exercised by TestAll. cookie is
+// not used: the cookie-validation case checks against cookieKey and
+// cookieValue directly.
+func buildParams(cookie *fasthttp.Cookie) []param {
+	return []param{
+		// Correct status.
+		{expectStatus: statusCode},
+		// Incorrect status.
+		{expectErr: true, expectStatus: fasthttp.StatusGatewayTimeout},
+
+		// Correct Content-Type.
+		{expectHeaders: map[string]string{"Content-Type": contextType}},
+		// Incorrect Content-Type.
+		{
+			expectErr:     true,
+			expectHeaders: map[string]string{"Content-Type": "application/json; charset=utf-8"},
 		},
-	})
-
-	// Correct response body.
-	testParams = append(testParams, param{
-		expectBody: respBody,
-	})
-	// Incorrect response body.
-	testParams = append(testParams, param{
-		expectErr:  true,
-		expectBody: "Incorrect response body",
-	})
 
-	// Add custom check function to validate cookie.
-	testParams = append(testParams, param{
-		expectCustoms: []Func{
-			func(resp fasthttp.Response) error {
-				cookie := &fasthttp.Cookie{}
-				cookie.SetKey(cookieKey)
-				if !resp.Header.Cookie(cookie) {
-					return fmt.Errorf("failed to get cookie")
-				}
-				if string(cookie.Value()) != cookieValue {
-					return fmt.Errorf("Expect cookie named %s: %q, got %q", cookieKey, cookieValue, cookie.Value())
-				}
-				return nil
+		// Correct response body.
+		{expectBody: respBody},
+		// Incorrect response body.
+		{expectErr: true, expectBody: "Incorrect response body"},
+
+		// Add custom check function to validate cookie.
+		{
+			expectCustoms: []Func{
+				func(resp fasthttp.Response) error {
+					c := &fasthttp.Cookie{}
+					c.SetKey(cookieKey)
+					if !resp.Header.Cookie(c) {
+						return fmt.Errorf("failed to get cookie")
+					}
+					if string(c.Value()) != cookieValue {
+						return fmt.Errorf("Expect cookie named %s: %q, got %q", cookieKey, cookieValue, c.Value())
+					}
+					return nil
+				},
 			},
 		},
-	})
 
-	// Test custom request header
-	testParams = append(testParams, param{
-		reqHeaders: map[string]string{
-			headerKey: headerValue,
-		},
-		expectCustoms: []Func{
-			func(resp fasthttp.Response) error {
-				bytesHeader := resp.Header.Peek(headerKey)
-				if len(bytesHeader) == 0 || string(bytesHeader) != headerValue {
-					return fmt.Errorf("Expect header named %s: %q, got %q", headerKey, headerValue, bytesHeader)
-				}
-				return nil
+		// Test custom request header
+		{
+			reqHeaders: map[string]string{headerKey: headerValue},
+			expectCustoms: []Func{
+				func(resp fasthttp.Response) error {
+					bytesHeader := resp.Header.Peek(headerKey)
+					if len(bytesHeader) == 0 || string(bytesHeader) != headerValue {
+						return fmt.Errorf("Expect header named %s: %q, got %q", headerKey, headerValue, bytesHeader)
+					}
+					return nil
+				},
 			},
 		},
-	})
 
-	// Test timeout
-	testParams = append(testParams, param{
-		reqUrl:    "/timeout",
-		expectErr: true,
-	})
+		// Test timeout: the handler sleeps 200ms on this path, so a
+		// much shorter Timeout must trip errTimeout.
+		{reqUrl: "/timeout", reqTimeout: 50 * time.Millisecond, expectErr: true},
+	}
 }
 
 func TestNew(t *testing.T) {
@@ -185,8 +175,10 @@ func check(t *Test, url, method, protocol string) error {
 }
 
 func TestAll(t *testing.T) {
+	srv, cookie := fixture()
+
 	var err error
-	for _, param := range testParams {
+	for _, param := range buildParams(cookie) {
 		test := New(srv)
 		initTest(test, &param)
 
@@ -218,6 +210,9 @@ func initTest(test *Test, param *param) {
 	if len(param.reqHeaders) > 0 {
 		test.Headers = param.reqHeaders
 	}
+	if param.reqTimeout > 0 {
+		test.Timeout = param.reqTimeout
+	}
 
 	// Expected result
 	if param.expectStatus > 0 {
@@ -238,6 +233,39 @@ func initTest(test *Test, param *param) {
 	}
 }
 
+// TestRunCases exercises the table-driven RunCases runner: each case
+// runs as its own isolated, parallel subtest against a fresh Test.
+func TestRunCases(t *testing.T) {
+	srv, _ := fixture()
+
+	test := New(srv)
+	test.RunCases(t, []Case{
+		{
+			Name:   "status",
+			Expect: *new(Expect).Status(statusCode),
+		},
+		{
+			Name:   "content-type",
+			Expect: *new(Expect).Header("Content-Type", contextType),
+		},
+		{
+			Name:   "body",
+			Expect: *new(Expect).Body(respBody),
+		},
+		{
+			Name:    "custom request header is echoed",
+			Request: Request{Headers: map[string]string{headerKey: headerValue}},
+			Expect: *new(Expect).Custom(func(resp fasthttp.Response) error {
+				v := resp.Header.Peek(headerKey)
+				if string(v) != headerValue {
+					return fmt.Errorf("expected header named %s: %q, got %q", headerKey, headerValue, v)
+				}
+				return nil
+			}),
+		},
+	})
+}
+
 func TestExpect_Rest(t *testing.T) {
 	e := new(Expect)
 	e.Status(fasthttp.StatusOK)
@@ -0,0 +1,125 @@
+// Copyright 2016 The Gem Authors. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+var errTimeout = errors.New("timeout")
+
+// Transport decides how a Test's request reaches its server and how
+// the response is read back. ctx governs cancellation: once ctx is
+// done, RoundTrip must abort the in-flight request and return
+// promptly instead of leaking the goroutine driving it.
+type Transport interface {
+	RoundTrip(ctx context.Context, t *Test) (fasthttp.Response, error)
+}
+
+// ServeConnTransport is the default Transport: it builds a raw HTTP
+// request in memory and feeds it straight to server.ServeConn,
+// bypassing real connection-level behavior.
+type ServeConnTransport struct{}
+
+// RoundTrip implements Transport.
+func (ServeConnTransport) RoundTrip(ctx context.Context, t *Test) (resp fasthttp.Response, err error) {
+	t.initRW()
+
+	br := bufio.NewReader(&t.rw.w)
+	ch := make(chan error, 1)
+	go func() {
+		ch <- t.server.ServeConn(t.rw)
+	}()
+
+	select {
+	case err = <-ch:
+		if err != nil {
+			return
+		}
+	case <-ctx.Done():
+		// Close makes any further read/write against the fake
+		// connection fail; the ServeConn goroutine above is left to
+		// drain on its own.
+		t.rw.Close()
+		return resp, errTimeout
+	}
+
+	if err = resp.Read(br); err != nil {
+		return resp, fmt.Errorf("unexpected error when reading response: %s", err)
+	}
+
+	return resp, nil
+}
+
+// netServer is implemented by servers that can listen on a real
+// net.Listener, such as *fasthttp.Server.
+type netServer interface {
+	Serve(net.Listener) error
+}
+
+// NetTransport runs the request over a real TCP connection: it
+// starts server on a loopback listener and dials it with a
+// fasthttp.HostClient, so keep-alive, Hijack and streaming responses
+// go through a real connection. The connection is plain TCP: no TLS
+// is set up and no PROXY protocol header is sent.
+//
+// Only ctx's deadline (or Test.Timeout when ctx has none) bounds the
+// request; cancelling ctx before that deadline does not abort it.
+type NetTransport struct{}
+
+// RoundTrip implements Transport.
+func (NetTransport) RoundTrip(ctx context.Context, t *Test) (resp fasthttp.Response, err error) {
+	srv, ok := t.server.(netServer)
+	if !ok {
+		return resp, fmt.Errorf("tests: server does not implement Serve(net.Listener) error, required by NetTransport")
+	}
+
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		return resp, fmt.Errorf("tests: failed to listen: %s", err)
+	}
+	defer ln.Close()
+
+	go srv.Serve(ln)
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+
+	req.Header.SetMethod(t.Method)
+	req.SetRequestURI(t.Url)
+	req.Header.SetHost(ln.Addr().String())
+	for k, v := range t.Headers {
+		req.Header.Set(k, v)
+	}
+	if t.Payload != "" {
+		req.SetBodyString(t.Payload)
+	}
+
+	client := &fasthttp.HostClient{Addr: ln.Addr().String()}
+
+	// DoDeadline blocks synchronously, so req and resp stay safe to
+	// release/read as soon as it returns: no background goroutine is
+	// left holding them past this call.
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(t.Timeout)
+	}
+
+	if err = client.DoDeadline(req, &resp, deadline); err != nil {
+		if err == fasthttp.ErrTimeout {
+			return resp, errTimeout
+		}
+		return resp, err
+	}
+
+	return resp, nil
+}
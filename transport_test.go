@@ -0,0 +1,37 @@
+// Copyright 2016 The Gem Authors. All rights reserved.
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file.
+
+package tests
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNetTransport(t *testing.T) {
+	srv, _ := fixture()
+
+	test := New(srv)
+	test.Transport = NetTransport{}
+	test.Expect().
+		Status(statusCode).
+		Header("Content-Type", contextType).
+		Body(respBody)
+
+	if err := test.Run(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNetTransport_Timeout(t *testing.T) {
+	srv, _ := fixture()
+
+	test := New(srv, "/timeout")
+	test.Transport = NetTransport{}
+	test.Timeout = 50 * time.Millisecond
+
+	if err := test.Run(); err == nil {
+		t.Error("expected timeout error, got nil")
+	}
+}